@@ -0,0 +1,119 @@
+// Package admin implements an HTTP control API for a running ScheduleManager, so
+// operators can inspect and override throttling without restarting the service.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"example.com/sysadmin/network_bandwidth_scheduler/scheduler"
+)
+
+// Server exposes:
+//
+//	GET  /status              - per-nic current state
+//	POST /throttle?nic=&reason= - force throttling on for nic until /clear or /reset
+//	POST /clear?nic=&reason=    - force throttling off for nic until /throttle or /reset
+//	POST /reset?nic=            - remove any override on nic, returning it to schedule-driven control
+//	POST /reload               - reparse the schedule file and apply it
+type Server struct {
+	manager   *scheduler.ScheduleManager
+	overrides *scheduler.OverrideStore
+	reload    func() error
+	http      *http.Server
+}
+
+// New returns a Server that will listen on addr. reload is invoked by POST /reload; it
+// should reparse whatever schedule file was used at startup and apply it to manager.
+func New(addr string, manager *scheduler.ScheduleManager, overrides *scheduler.OverrideStore, reload func() error) *Server {
+	s := &Server{manager: manager, overrides: overrides, reload: reload}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/throttle", s.handleThrottle)
+	mux.HandleFunc("/clear", s.handleClear)
+	mux.HandleFunc("/reset", s.handleReset)
+	mux.HandleFunc("/reload", s.handleReload)
+	s.http = &http.Server{Addr: addr, Handler: mux}
+	return s
+}
+
+// ListenAndServe runs the admin server until it's Shutdown or encounters an error.
+func (s *Server) ListenAndServe() error {
+	return s.http.ListenAndServe()
+}
+
+// Shutdown gracefully stops the admin server.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.http.Shutdown(ctx)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "GET only", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.manager.Status()); err != nil {
+		log.Printf("admin: error encoding /status response: %v\n", err)
+	}
+}
+
+func (s *Server) handleThrottle(w http.ResponseWriter, r *http.Request) {
+	s.handleOverride(w, r, true)
+}
+
+func (s *Server) handleClear(w http.ResponseWriter, r *http.Request) {
+	s.handleOverride(w, r, false)
+}
+
+func (s *Server) handleOverride(w http.ResponseWriter, r *http.Request, shouldThrottle bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		return
+	}
+	nic := r.URL.Query().Get("nic")
+	if nic == "" {
+		http.Error(w, "nic is required", http.StatusBadRequest)
+		return
+	}
+	if _, ok := s.manager.Scheduler(nic); !ok {
+		http.Error(w, fmt.Sprintf("unknown nic %q", nic), http.StatusNotFound)
+		return
+	}
+	reason := r.URL.Query().Get("reason")
+	s.overrides.Set(nic, shouldThrottle, reason)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		return
+	}
+	nic := r.URL.Query().Get("nic")
+	if nic == "" {
+		http.Error(w, "nic is required", http.StatusBadRequest)
+		return
+	}
+	if _, ok := s.manager.Scheduler(nic); !ok {
+		http.Error(w, fmt.Sprintf("unknown nic %q", nic), http.StatusNotFound)
+		return
+	}
+	s.overrides.Clear(nic)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.reload(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
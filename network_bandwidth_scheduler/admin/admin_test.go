@@ -0,0 +1,126 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"example.com/sysadmin/network_bandwidth_scheduler/scheduler"
+	"example.com/sysadmin/network_bandwidth_scheduler/throttle/fake"
+	"github.com/karagog/clock-go/simulated"
+)
+
+func newTestManager(t *testing.T) *scheduler.ScheduleManager {
+	t.Helper()
+	clk := simulated.NewClock(time.Date(2023, 12, 30, 1, 0, 0, 0, time.UTC))
+	cfg := &scheduler.Config{Interfaces: []scheduler.InterfaceConfig{{
+		Nic: "eth0",
+		Rules: []scheduler.RuleConfig{{
+			Weekdays: []string{"all"}, Start: "00:00", End: "12:00", DownloadKbps: 1000, UploadKbps: 500,
+		}},
+	}}}
+	m, err := scheduler.NewScheduleManager(cfg, fake.New(), clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestHandleStatus(t *testing.T) {
+	m := newTestManager(t)
+	s := New(":0", m, scheduler.NewOverrideStore(), func() error { return nil })
+
+	rec := httptest.NewRecorder()
+	s.handleStatus(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestHandleThrottleAndClear(t *testing.T) {
+	m := newTestManager(t)
+	overrides := scheduler.NewOverrideStore()
+	s := New(":0", m, overrides, func() error { return nil })
+
+	rec := httptest.NewRecorder()
+	s.handleThrottle(rec, httptest.NewRequest(http.MethodPost, "/throttle?nic=eth0&reason=backup_running", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleThrottle(rec, httptest.NewRequest(http.MethodPost, "/throttle?reason=backup_running", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("missing nic: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleThrottle(rec, httptest.NewRequest(http.MethodPost, "/throttle?nic=doesnotexist&reason=x", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown nic: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleClear(rec, httptest.NewRequest(http.MethodPost, "/clear?nic=eth0&reason=maintenance", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestHandleReset(t *testing.T) {
+	m := newTestManager(t)
+	overrides := scheduler.NewOverrideStore()
+	s := New(":0", m, overrides, func() error { return nil })
+
+	overrides.Set("eth0", true, "backup_running")
+	notified := false
+	overrides.Subscribe("eth0", func() { notified = true })
+
+	rec := httptest.NewRecorder()
+	s.handleReset(rec, httptest.NewRequest(http.MethodPost, "/reset?nic=eth0", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if !notified {
+		t.Fatal("/reset did not clear the override for eth0")
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleReset(rec, httptest.NewRequest(http.MethodPost, "/reset", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("missing nic: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleReset(rec, httptest.NewRequest(http.MethodPost, "/reset?nic=doesnotexist", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown nic: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleReload(t *testing.T) {
+	m := newTestManager(t)
+	called := false
+	s := New(":0", m, scheduler.NewOverrideStore(), func() error { called = true; return nil })
+
+	rec := httptest.NewRecorder()
+	s.handleReload(rec, httptest.NewRequest(http.MethodPost, "/reload", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if !called {
+		t.Fatal("reload callback was not invoked")
+	}
+}
+
+func TestHandleWrongMethod(t *testing.T) {
+	m := newTestManager(t)
+	s := New(":0", m, scheduler.NewOverrideStore(), func() error { return nil })
+
+	rec := httptest.NewRecorder()
+	s.handleStatus(rec, httptest.NewRequest(http.MethodPost, "/status", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -1,28 +1,111 @@
 // Package network_bandwidth_scheduler implements a service that applies bandwidth
 // limitations during certain time windows. The default behavior is to limit bandwidth at
-// all times, unless different values of "start" and "end" time are given.
+// all times, unless different values of "start" and "end" time are given, or a
+// --config file is supplied for richer, multi-interface schedules.
 package main
 
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"example.com/sysadmin/network_bandwidth_scheduler/admin"
 	"example.com/sysadmin/network_bandwidth_scheduler/scheduler"
+	"example.com/sysadmin/network_bandwidth_scheduler/throttle"
+	"example.com/sysadmin/network_bandwidth_scheduler/throttle/nftables"
+	"example.com/sysadmin/network_bandwidth_scheduler/throttle/tc"
+	"example.com/sysadmin/network_bandwidth_scheduler/throttle/wondershaper"
 	"github.com/karagog/clock-go/real"
 )
 
-var start = flag.Duration("throttle_start_time", 0, "Throttling starts at this time of day.")
-var end = flag.Duration("throttle_end_time", 0, "Throttling ends at this time of day.")
-var nic = flag.String("nic", "", "The network interface to apply throttling.")
+var start = flag.Duration("throttle_start_time", 0, "Throttling starts at this time of day. Ignored if --config is set.")
+var end = flag.Duration("throttle_end_time", 0, "Throttling ends at this time of day. Ignored if --config is set.")
+var nic = flag.String("nic", "", "The network interface to apply throttling. Ignored if --config is set.")
+var downloadKbps = flag.Int("download_kbps", 10000, "Throttle the download rate to this value. Ignored if --config is set. Used as the adaptive max if --adaptive is set.")
+var uploadKbps = flag.Int("upload_kbps", 10000, "Throttle the upload rate to this value. Ignored if --config is set. Used as the adaptive max if --adaptive is set.")
+var adaptive = flag.Bool("adaptive", false, "If set, cap download_kbps/upload_kbps to a target fraction of recently measured throughput instead of applying them as fixed caps. Ignored if --config is set.")
+var adaptiveTargetUtilization = flag.Float64("adaptive_target_utilization", 0.8, "Target fraction of recently measured throughput to cap at, when --adaptive is set.")
+var adaptiveMinKbps = flag.Int("adaptive_min_kbps", 1000, "Minimum adaptive cap, when --adaptive is set.")
+var adaptiveHysteresis = flag.Float64("adaptive_hysteresis", 0.1, "Fraction the adaptive target must deviate from the currently applied cap before it's changed, when --adaptive is set.")
+var backend = flag.String("backend", "wondershaper", "The throttling backend to use: 'wondershaper', 'tc', or 'nftables'. 'wondershaper' and 'tc' shape traffic by queuing it with HTB; 'nftables' polices it, dropping packets over the rate instead, which is burstier under load.")
+var wondershaperPath = flag.String("wondershaper_path", "/usr/local/sbin/wondershaper", "The path to 'wondershaper', used when --backend=wondershaper")
+var tcPath = flag.String("tc_path", "/usr/sbin/tc", "The path to 'tc', used when --backend=tc")
+var ipPath = flag.String("ip_path", "/usr/sbin/ip", "The path to 'ip', used when --backend=tc to bring up the IFB device ingress shaping redirects onto")
+var nftPath = flag.String("nft_path", "/usr/sbin/nft", "The path to 'nft', used when --backend=nftables")
+var configPath = flag.String("config", "", "Path to a YAML or JSON scheduler.Config file. When set, it takes over scheduling for all interfaces it lists, instead of --nic/--throttle_start_time/--throttle_end_time.")
+var adminAddr = flag.String("admin_addr", "", "If set, serve an HTTP admin API (GET /status, POST /throttle, POST /clear, POST /reset, POST /reload) on this address.")
+
+func newThrottler(backend string) (throttle.Throttler, error) {
+	switch backend {
+	case "wondershaper":
+		return wondershaper.New(*wondershaperPath), nil
+	case "tc":
+		return tc.New(*tcPath, *ipPath), nil
+	case "nftables":
+		return nftables.New(*nftPath), nil
+	default:
+		return nil, fmt.Errorf("unknown --backend: %q", backend)
+	}
+}
+
+// loadConfig returns the scheduler.Config describing what to run, built either from
+// --config or, failing that, from the legacy --nic/--throttle_start_time/--throttle_end_time
+// flags.
+func loadConfig() (*scheduler.Config, error) {
+	if *configPath != "" {
+		return scheduler.LoadConfig(*configPath)
+	}
+	if *nic == "" {
+		return nil, fmt.Errorf("either --config or --nic must be specified")
+	}
+	rule := scheduler.RuleConfig{
+		Weekdays:     []string{"all"},
+		Start:        formatTimeOfDay(*start),
+		End:          formatTimeOfDay(*end),
+		DownloadKbps: *downloadKbps,
+		UploadKbps:   *uploadKbps,
+	}
+	if *adaptive {
+		rule.AdaptiveDownload = &scheduler.AdaptiveProfileConfig{
+			TargetUtilization: *adaptiveTargetUtilization,
+			MinKbps:           *adaptiveMinKbps,
+			MaxKbps:           *downloadKbps,
+			Hysteresis:        *adaptiveHysteresis,
+		}
+		rule.AdaptiveUpload = &scheduler.AdaptiveProfileConfig{
+			TargetUtilization: *adaptiveTargetUtilization,
+			MinKbps:           *adaptiveMinKbps,
+			MaxKbps:           *uploadKbps,
+			Hysteresis:        *adaptiveHysteresis,
+		}
+	}
+	return &scheduler.Config{Interfaces: []scheduler.InterfaceConfig{{
+		Nic:   *nic,
+		Rules: []scheduler.RuleConfig{rule},
+	}}}, nil
+}
+
+func formatTimeOfDay(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours())%24, int(d.Minutes())%60)
+}
 
 func main() {
 	flag.Parse()
-	if *nic == "" {
-		log.Fatal("--nic must be specified")
+
+	throttler, err := newThrottler(*backend)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -40,11 +123,33 @@ func main() {
 		}
 	}()
 
-	// Run the scheduler until canceled.
-	s, err := scheduler.New(*nic, *start, *end, &real.Clock{})
+	m, err := scheduler.NewScheduleManager(cfg, throttler, &real.Clock{})
 	if err != nil {
-		log.Fatalf("Cannot initialize scheduler: %v", err)
+		log.Fatalf("Cannot initialize schedule manager: %v", err)
 	}
-	defer s.Close()
-	s.Run(ctx)
+
+	if *adminAddr != "" {
+		overrides := scheduler.NewOverrideStore()
+		m.SetOverrides(overrides)
+		srv := admin.New(*adminAddr, m, overrides, func() error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			return m.Reload(cfg)
+		})
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("admin server exited: %v\n", err)
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdownCtx)
+		}()
+	}
+
+	m.Run(ctx)
 }
@@ -0,0 +1,34 @@
+package scheduler
+
+import "math"
+
+// AdaptiveProfile caps bandwidth as a function of recently measured throughput instead of
+// a fixed value: the cap tracks TargetUtilization of the observed throughput during the
+// prior window, bounded by [MinKbps, MaxKbps]. To avoid thrashing the cap up and down in
+// response to noise, it's only changed when the new target deviates from the currently
+// applied cap by more than Hysteresis (a fraction, e.g. 0.1 for 10%).
+type AdaptiveProfile struct {
+	TargetUtilization float64
+	MinKbps, MaxKbps  int
+	Hysteresis        float64
+}
+
+// nextCap returns the cap that should be applied given observedKbps, the throughput
+// measured during the prior window, and currentKbps, the cap currently in effect (0 if
+// none has been applied yet).
+func (ap *AdaptiveProfile) nextCap(observedKbps float64, currentKbps int) int {
+	target := observedKbps * ap.TargetUtilization
+	if target < float64(ap.MinKbps) {
+		target = float64(ap.MinKbps)
+	}
+	if target > float64(ap.MaxKbps) {
+		target = float64(ap.MaxKbps)
+	}
+	if currentKbps == 0 {
+		return int(target)
+	}
+	if math.Abs(target-float64(currentKbps))/float64(currentKbps) <= ap.Hysteresis {
+		return currentKbps
+	}
+	return int(target)
+}
@@ -0,0 +1,27 @@
+package scheduler
+
+import "testing"
+
+func TestAdaptiveProfileNextCap(t *testing.T) {
+	ap := &AdaptiveProfile{TargetUtilization: 0.8, MinKbps: 1000, MaxKbps: 10000, Hysteresis: 0.1}
+
+	testCases := []struct {
+		desc     string
+		observed float64
+		current  int
+		want     int
+	}{
+		{"no prior cap adopts target immediately", 5000, 0, 4000},
+		{"within hysteresis keeps current", 5000, 4100, 4100},
+		{"deviation beyond hysteresis adopts target", 5000, 2000, 4000},
+		{"clamps to floor", 100, 3000, 1000},
+		{"clamps to ceiling", 100000, 3000, 10000},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := ap.nextCap(tc.observed, tc.current); got != tc.want {
+				t.Fatalf("nextCap(%v, %v) = %v, want %v", tc.observed, tc.current, got, tc.want)
+			}
+		})
+	}
+}
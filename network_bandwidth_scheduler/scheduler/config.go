@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the on-disk schedule definition for one or more network interfaces.
+type Config struct {
+	Interfaces []InterfaceConfig `yaml:"interfaces" json:"interfaces"`
+}
+
+// InterfaceConfig is the schedule for a single network interface.
+type InterfaceConfig struct {
+	Nic   string       `yaml:"nic" json:"nic"`
+	Rules []RuleConfig `yaml:"rules" json:"rules"`
+}
+
+// RuleConfig is the on-disk representation of a Rule. Weekdays accepts day names
+// ("monday", ...), or the shorthands "all", "weekdays", and "weekend". Start and End are
+// "HH:MM" times of day. Profile is either Unlimited, DownloadKbps/UploadKbps, or
+// AdaptiveDownload/AdaptiveUpload in place of the corresponding fixed Kbps field.
+type RuleConfig struct {
+	Weekdays         []string               `yaml:"weekdays" json:"weekdays"`
+	Start            string                 `yaml:"start" json:"start"`
+	End              string                 `yaml:"end" json:"end"`
+	Priority         int                    `yaml:"priority" json:"priority"`
+	Unlimited        bool                   `yaml:"unlimited" json:"unlimited"`
+	DownloadKbps     int                    `yaml:"download_kbps" json:"download_kbps"`
+	UploadKbps       int                    `yaml:"upload_kbps" json:"upload_kbps"`
+	AdaptiveDownload *AdaptiveProfileConfig `yaml:"adaptive_download,omitempty" json:"adaptive_download,omitempty"`
+	AdaptiveUpload   *AdaptiveProfileConfig `yaml:"adaptive_upload,omitempty" json:"adaptive_upload,omitempty"`
+}
+
+// AdaptiveProfileConfig is the on-disk representation of an AdaptiveProfile.
+type AdaptiveProfileConfig struct {
+	TargetUtilization float64 `yaml:"target_utilization" json:"target_utilization"`
+	MinKbps           int     `yaml:"min_kbps" json:"min_kbps"`
+	MaxKbps           int     `yaml:"max_kbps" json:"max_kbps"`
+	Hysteresis        float64 `yaml:"hysteresis" json:"hysteresis"`
+}
+
+func (ac *AdaptiveProfileConfig) profile() *AdaptiveProfile {
+	if ac == nil {
+		return nil
+	}
+	return &AdaptiveProfile{
+		TargetUtilization: ac.TargetUtilization,
+		MinKbps:           ac.MinKbps,
+		MaxKbps:           ac.MaxKbps,
+		Hysteresis:        ac.Hysteresis,
+	}
+}
+
+// LoadConfig reads a Config from a YAML (.yaml/.yml) or JSON (.json) file at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config %q: %w", path, err)
+	}
+
+	var cfg Config
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("parsing config %q: %w", path, err)
+		}
+	case ".json":
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("parsing config %q: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unrecognized config extension %q, want .yaml, .yml, or .json", ext)
+	}
+	return &cfg, nil
+}
+
+// resolveRules converts the interface's on-disk RuleConfigs into runtime Rules.
+func (c *InterfaceConfig) resolveRules() ([]Rule, error) {
+	rules := make([]Rule, 0, len(c.Rules))
+	for i, rc := range c.Rules {
+		r, err := rc.rule()
+		if err != nil {
+			return nil, fmt.Errorf("nic %q rule %d: %w", c.Nic, i, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func (rc *RuleConfig) rule() (Rule, error) {
+	weekdays, err := parseWeekdays(rc.Weekdays)
+	if err != nil {
+		return Rule{}, err
+	}
+	start, err := parseTimeOfDay(rc.Start)
+	if err != nil {
+		return Rule{}, fmt.Errorf("start: %w", err)
+	}
+	end, err := parseTimeOfDay(rc.End)
+	if err != nil {
+		return Rule{}, fmt.Errorf("end: %w", err)
+	}
+	return Rule{
+		Weekdays: weekdays,
+		Start:    start,
+		End:      end,
+		Priority: rc.Priority,
+		Profile: Profile{
+			Unlimited:        rc.Unlimited,
+			DownloadKbps:     rc.DownloadKbps,
+			UploadKbps:       rc.UploadKbps,
+			AdaptiveDownload: rc.AdaptiveDownload.profile(),
+			AdaptiveUpload:   rc.AdaptiveUpload.profile(),
+		},
+	}, nil
+}
+
+var namedWeekdays = map[string]Weekday{
+	"sunday":    Sunday,
+	"monday":    Monday,
+	"tuesday":   Tuesday,
+	"wednesday": Wednesday,
+	"thursday":  Thursday,
+	"friday":    Friday,
+	"saturday":  Saturday,
+	"all":       AllWeek,
+	"weekdays":  Weekdays,
+	"weekend":   Weekend,
+}
+
+func parseWeekdays(names []string) (Weekday, error) {
+	var mask Weekday
+	for _, name := range names {
+		bit, ok := namedWeekdays[strings.ToLower(name)]
+		if !ok {
+			return 0, fmt.Errorf("unrecognized weekday %q", name)
+		}
+		mask |= bit
+	}
+	return mask, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	hh, mm, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("expected HH:MM, got %q", s)
+	}
+	h, err := strconv.Atoi(hh)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", s)
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", s)
+	}
+	if h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("expected HH:MM with HH in [0,23] and MM in [0,59], got %q", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigYAML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schedule.yaml")
+	yaml := `
+interfaces:
+  - nic: eth0
+    rules:
+      - weekdays: [weekdays]
+        start: "09:00"
+        end: "17:00"
+        download_kbps: 1000
+        upload_kbps: 500
+      - weekdays: [weekend]
+        start: "00:00"
+        end: "00:00"
+        unlimited: true
+`
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Interfaces) != 1 || cfg.Interfaces[0].Nic != "eth0" {
+		t.Fatalf("got %+v, want one interface named eth0", cfg.Interfaces)
+	}
+	rules, err := cfg.Interfaces[0].resolveRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("got %d rules, want 2", len(rules))
+	}
+	if rules[0].Weekdays != Weekdays || rules[0].Start != 9*time.Hour || rules[0].End != 17*time.Hour {
+		t.Fatalf("rule 0 = %+v, want a 9-17 weekdays rule", rules[0])
+	}
+	if !rules[1].Profile.Unlimited {
+		t.Fatalf("rule 1 = %+v, want Unlimited", rules[1])
+	}
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schedule.json")
+	json := `{"interfaces":[{"nic":"eth1","rules":[{"weekdays":["all"],"start":"22:00","end":"06:00","download_kbps":500,"upload_kbps":500}]}]}`
+	if err := os.WriteFile(path, []byte(json), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := cfg.Interfaces[0].resolveRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules[0].Weekdays != AllWeek {
+		t.Fatalf("got weekdays %v, want AllWeek", rules[0].Weekdays)
+	}
+}
+
+func TestLoadConfigYAMLAdaptive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schedule.yaml")
+	yaml := `
+interfaces:
+  - nic: eth0
+    rules:
+      - weekdays: [all]
+        start: "00:00"
+        end: "00:00"
+        adaptive_download:
+          target_utilization: 0.5
+          min_kbps: 1000
+          max_kbps: 100000
+          hysteresis: 0.1
+`
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := cfg.Interfaces[0].resolveRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ap := rules[0].Profile.AdaptiveDownload
+	if ap == nil {
+		t.Fatal("AdaptiveDownload = nil, want an AdaptiveProfile")
+	}
+	if ap.TargetUtilization != 0.5 || ap.MinKbps != 1000 || ap.MaxKbps != 100000 || ap.Hysteresis != 0.1 {
+		t.Fatalf("AdaptiveDownload = %+v, want TargetUtilization=0.5 MinKbps=1000 MaxKbps=100000 Hysteresis=0.1", ap)
+	}
+	if rules[0].Profile.AdaptiveUpload != nil {
+		t.Fatalf("AdaptiveUpload = %+v, want nil", rules[0].Profile.AdaptiveUpload)
+	}
+}
+
+func TestLoadConfigUnrecognizedExtension(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schedule.txt")
+	if err := os.WriteFile(path, []byte("nic: eth0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("Got nil error, want error")
+	}
+}
+
+func TestParseWeekdaysUnrecognized(t *testing.T) {
+	if _, err := parseWeekdays([]string{"funday"}); err == nil {
+		t.Fatal("Got nil error, want error")
+	}
+}
+
+func TestParseTimeOfDayInvalid(t *testing.T) {
+	for _, s := range []string{"9am", "25:00", "9"} {
+		if _, err := parseTimeOfDay(s); err == nil {
+			t.Fatalf("parseTimeOfDay(%q): got nil error, want error", s)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"example.com/sysadmin/network_bandwidth_scheduler/throttle"
+	"example.com/sysadmin/network_bandwidth_scheduler/throughput"
+	"github.com/karagog/clock-go"
+)
+
+const (
+	// adaptiveSampleInterval is how often a throughput.Monitor samples an interface's
+	// byte counters, for nics with at least one rule using an adaptive profile.
+	adaptiveSampleInterval = 5 * time.Second
+
+	// adaptiveHistoryWindow is how much recent throughput history a Monitor retains.
+	adaptiveHistoryWindow = 5 * time.Minute
+)
+
+// ScheduleManager owns one Scheduler per network interface described in a Config, and
+// runs them all concurrently.
+type ScheduleManager struct {
+	schedulers map[string]*Scheduler
+	monitors   map[string]*throughput.Monitor // nics with at least one adaptive rule
+}
+
+// NewScheduleManager builds a Scheduler for every interface in cfg, all sharing
+// throttler, and returns a ScheduleManager that runs them together.
+func NewScheduleManager(cfg *Config, throttler throttle.Throttler, clock clock.Clock) (*ScheduleManager, error) {
+	rulesByNic := make(map[string][]Rule, len(cfg.Interfaces))
+	for _, ifc := range cfg.Interfaces {
+		rules, err := ifc.resolveRules()
+		if err != nil {
+			return nil, fmt.Errorf("nic %q: %w", ifc.Nic, err)
+		}
+		rulesByNic[ifc.Nic] = rules
+	}
+	return NewScheduleManagerFromRules(rulesByNic, throttler, clock)
+}
+
+// NewScheduleManagerFromRules is like NewScheduleManager, but takes already-parsed Rules
+// per nic instead of a Config. It's the building block callers use when their schedule
+// didn't come from a config file, e.g. a single interface configured entirely by flags.
+//
+// A nic whose rules include an adaptive profile gets a throughput.Monitor constructed and
+// attached automatically, sampling /sys/class/net via throughput.SysfsSampler; Run starts
+// it alongside the nic's Scheduler.
+func NewScheduleManagerFromRules(rulesByNic map[string][]Rule, throttler throttle.Throttler, clock clock.Clock) (*ScheduleManager, error) {
+	schedulers := make(map[string]*Scheduler, len(rulesByNic))
+	monitors := make(map[string]*throughput.Monitor)
+	for nic, rules := range rulesByNic {
+		s, err := New(nic, rules, throttler, clock)
+		if err != nil {
+			return nil, fmt.Errorf("nic %q: %w", nic, err)
+		}
+		if hasAdaptiveRule(rules) {
+			monitor := throughput.NewMonitor(nic, throughput.SysfsSampler{}, clock, adaptiveHistoryWindow)
+			s.SetMonitor(monitor, 0)
+			monitors[nic] = monitor
+		}
+		schedulers[nic] = s
+	}
+	return &ScheduleManager{schedulers: schedulers, monitors: monitors}, nil
+}
+
+// hasAdaptiveRule reports whether any rule's Profile uses AdaptiveDownload/AdaptiveUpload,
+// and therefore needs a throughput.Monitor attached.
+func hasAdaptiveRule(rules []Rule) bool {
+	for _, r := range rules {
+		if r.Profile.AdaptiveDownload != nil || r.Profile.AdaptiveUpload != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Run starts every managed Scheduler (via Start, not Run directly) and throughput.Monitor,
+// and blocks until ctx is done and the schedulers have all torn down their throttling.
+// Starting schedulers through Start/Stop, rather than running their loops inline, is what
+// lets Reload restart an individual nic's Scheduler without disturbing the others.
+func (m *ScheduleManager) Run(ctx context.Context) {
+	for nic, s := range m.schedulers {
+		if err := s.Start(); err != nil {
+			log.Printf("nic %q: starting scheduler: %v\n", nic, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(m.monitors))
+	for _, mon := range m.monitors {
+		go func(mon *throughput.Monitor) {
+			defer wg.Done()
+			mon.Run(ctx, adaptiveSampleInterval)
+		}(mon)
+	}
+
+	<-ctx.Done()
+	for nic, s := range m.schedulers {
+		if err := s.Stop(); err != nil {
+			log.Printf("nic %q: stopping scheduler: %v\n", nic, err)
+		}
+	}
+	wg.Wait()
+}
+
+// Scheduler returns the Scheduler managing nic, if any.
+func (m *ScheduleManager) Scheduler(nic string) (*Scheduler, bool) {
+	s, ok := m.schedulers[nic]
+	return s, ok
+}
+
+// Status reports the current state of every managed interface, sorted by nic.
+func (m *ScheduleManager) Status() []Status {
+	statuses := make([]Status, 0, len(m.schedulers))
+	for _, s := range m.schedulers {
+		statuses = append(statuses, s.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Nic < statuses[j].Nic })
+	return statuses
+}
+
+// SetOverrides wires store into every managed Scheduler, so admin overrides take effect
+// immediately rather than waiting for the next scheduled transition.
+func (m *ScheduleManager) SetOverrides(store *OverrideStore) {
+	for _, s := range m.schedulers {
+		s.SetOverrides(store)
+	}
+}
+
+// Reload re-applies cfg's rules to the already-running schedulers. It returns an error,
+// and applies nothing, if cfg references a nic that wasn't part of the original Config;
+// adding a new interface requires a restart of the whole process.
+//
+// A nic whose Scheduler is running is restarted (Stop, then Start again) around the
+// SetRules call, so its throttling is fully torn down and reapplied from the new rules
+// without disturbing any other nic's Scheduler.
+func (m *ScheduleManager) Reload(cfg *Config) error {
+	rulesByNic := make(map[string][]Rule, len(cfg.Interfaces))
+	for _, ifc := range cfg.Interfaces {
+		if _, ok := m.schedulers[ifc.Nic]; !ok {
+			return fmt.Errorf("nic %q is not part of the running schedule; restart to add new interfaces", ifc.Nic)
+		}
+		rules, err := ifc.resolveRules()
+		if err != nil {
+			return fmt.Errorf("nic %q: %w", ifc.Nic, err)
+		}
+		rulesByNic[ifc.Nic] = rules
+	}
+	for nic, rules := range rulesByNic {
+		s := m.schedulers[nic]
+		wasRunning := s.IsRunning()
+		if wasRunning {
+			if err := s.Stop(); err != nil {
+				return fmt.Errorf("nic %q: stopping to reload: %w", nic, err)
+			}
+		}
+		if err := s.SetRules(rules); err != nil {
+			return fmt.Errorf("nic %q: %w", nic, err)
+		}
+		if wasRunning {
+			if err := s.Start(); err != nil {
+				return fmt.Errorf("nic %q: restarting after reload: %w", nic, err)
+			}
+		}
+	}
+	return nil
+}
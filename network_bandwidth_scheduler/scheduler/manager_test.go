@@ -0,0 +1,162 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/sysadmin/network_bandwidth_scheduler/throttle/fake"
+	"github.com/karagog/clock-go/simulated"
+)
+
+func testConfig() *Config {
+	return &Config{Interfaces: []InterfaceConfig{{
+		Nic: "eth0",
+		Rules: []RuleConfig{{
+			Weekdays: []string{"all"}, Start: "00:00", End: "12:00", DownloadKbps: 1000, UploadKbps: 500,
+		}},
+	}}}
+}
+
+func TestScheduleManagerStatus(t *testing.T) {
+	clk := simulated.NewClock(time.Date(2023, 12, 30, 1, 0, 0, 0, time.UTC))
+	m, err := NewScheduleManager(testConfig(), fake.New(), clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := m.Status()
+	if len(statuses) != 1 || statuses[0].Nic != "eth0" {
+		t.Fatalf("Status() = %+v, want one status for eth0", statuses)
+	}
+	if !statuses[0].Throttling {
+		t.Fatalf("Status() = %+v, want throttling at 1am", statuses[0])
+	}
+}
+
+func TestScheduleManagerAttachesMonitorForAdaptiveRule(t *testing.T) {
+	clk := simulated.NewClock(time.Now())
+	cfg := &Config{Interfaces: []InterfaceConfig{{
+		Nic: "eth0",
+		Rules: []RuleConfig{{
+			Weekdays: []string{"all"}, Start: "00:00", End: "00:00",
+			AdaptiveDownload: &AdaptiveProfileConfig{TargetUtilization: 0.5, MinKbps: 100, MaxKbps: 10000, Hysteresis: 0.1},
+		}},
+	}}}
+	m, err := NewScheduleManager(cfg, fake.New(), clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.monitors["eth0"]; !ok {
+		t.Fatal("no throughput.Monitor attached for a nic with an adaptive rule")
+	}
+}
+
+func TestScheduleManagerNoMonitorForFixedRule(t *testing.T) {
+	clk := simulated.NewClock(time.Now())
+	m, err := NewScheduleManager(testConfig(), fake.New(), clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.monitors) != 0 {
+		t.Fatalf("monitors = %v, want none for a config with no adaptive rules", m.monitors)
+	}
+}
+
+func TestScheduleManagerRunStartsAndStopsSchedulers(t *testing.T) {
+	clk := simulated.NewClock(time.Date(2023, 12, 30, 1, 0, 0, 0, time.UTC))
+	m, err := NewScheduleManager(testConfig(), fake.New(), clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := m.Scheduler("eth0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		m.Run(ctx)
+	}()
+	for !s.IsRunning() {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-runDone:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not return after ctx was canceled")
+	}
+	if s.IsRunning() {
+		t.Fatal("IsRunning() = true after Run() returned")
+	}
+}
+
+func TestScheduleManagerReloadRestartsRunningScheduler(t *testing.T) {
+	clk := simulated.NewClock(time.Date(2023, 12, 30, 1, 0, 0, 0, time.UTC))
+	o := fake.New()
+	m, err := NewScheduleManager(testConfig(), o, clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := m.Scheduler("eth0")
+	clearsBefore := o.ClearCount // New already cleared once
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond) // let Run apply the initial state
+
+	cfg := &Config{Interfaces: []InterfaceConfig{{
+		Nic:   "eth0",
+		Rules: []RuleConfig{{Weekdays: []string{"all"}, Start: "00:00", End: "00:00", Unlimited: true}},
+	}}}
+	if err := m.Reload(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.IsRunning() {
+		t.Fatal("IsRunning() = false after Reload, want the scheduler restarted")
+	}
+	if got := s.Status(); got.Throttling {
+		t.Fatalf("Status() = %+v, want Unlimited after reload", got)
+	}
+
+	// Stop before reading the fake throttler's counters: they're read here without the
+	// fake's lock, so only safe to read once Run's goroutine has exited.
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if got := o.ClearCount - clearsBefore; got < 3 {
+		t.Fatalf("ClearCount rose by %d, want at least 3: Reload should Stop (tearing down) then Start (reapplying Unlimited), then the final Stop tears down again", got)
+	}
+}
+
+func TestScheduleManagerReloadUnknownNic(t *testing.T) {
+	clk := simulated.NewClock(time.Now())
+	m, err := NewScheduleManager(testConfig(), fake.New(), clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{Interfaces: []InterfaceConfig{{Nic: "eth1", Rules: []RuleConfig{{Weekdays: []string{"all"}, Start: "00:00", End: "00:00"}}}}}
+	if err := m.Reload(cfg); err == nil {
+		t.Fatal("Got nil error, want error reloading an unknown nic")
+	}
+}
+
+func TestScheduleManagerReloadAppliesNewRules(t *testing.T) {
+	clk := simulated.NewClock(time.Date(2023, 12, 30, 1, 0, 0, 0, time.UTC))
+	m, err := NewScheduleManager(testConfig(), fake.New(), clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{Interfaces: []InterfaceConfig{{
+		Nic:   "eth0",
+		Rules: []RuleConfig{{Weekdays: []string{"all"}, Start: "00:00", End: "00:00", Unlimited: true}},
+	}}}
+	if err := m.Reload(cfg); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := m.Scheduler("eth0")
+	if got := s.Status(); got.Throttling {
+		t.Fatalf("Status() = %+v, want Unlimited after reload", got)
+	}
+}
@@ -0,0 +1,76 @@
+package scheduler
+
+import "sync"
+
+// ThrottleCheckResult is the outcome of deciding whether a nic should currently be
+// throttled, and why. Reason is a short machine-usable tag ("schedule" or "override");
+// ReasonHint carries human-readable detail, such as why an override was requested.
+type ThrottleCheckResult struct {
+	ShouldThrottle bool
+	Profile        Profile
+	Reason         string
+	ReasonHint     string
+}
+
+type override struct {
+	shouldThrottle bool
+	reason         string
+}
+
+// OverrideStore holds manual per-nic overrides that take priority over a Scheduler's
+// normal Rule-based schedule. It's the integration point for anything that needs to force
+// throttling on or off outside of the schedule: the admin HTTP API today, and in the
+// future things like a systemd inhibit lock or a SIGUSR1-triggered toggle.
+type OverrideStore struct {
+	mu        sync.Mutex
+	overrides map[string]override
+	onChange  map[string][]func()
+}
+
+// NewOverrideStore returns an empty OverrideStore.
+func NewOverrideStore() *OverrideStore {
+	return &OverrideStore{
+		overrides: make(map[string]override),
+		onChange:  make(map[string][]func()),
+	}
+}
+
+// Subscribe registers notify to be called, from whatever goroutine calls Set or Clear,
+// whenever the override for nic changes.
+func (s *OverrideStore) Subscribe(nic string, notify func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange[nic] = append(s.onChange[nic], notify)
+}
+
+// Set forces nic's throttling state to shouldThrottle until Clear is called, recording
+// reason for /status and logging.
+func (s *OverrideStore) Set(nic string, shouldThrottle bool, reason string) {
+	s.mu.Lock()
+	s.overrides[nic] = override{shouldThrottle: shouldThrottle, reason: reason}
+	notify := append([]func(){}, s.onChange[nic]...)
+	s.mu.Unlock()
+
+	for _, f := range notify {
+		f()
+	}
+}
+
+// Clear removes any override on nic, returning it to schedule-driven control.
+func (s *OverrideStore) Clear(nic string) {
+	s.mu.Lock()
+	delete(s.overrides, nic)
+	notify := append([]func(){}, s.onChange[nic]...)
+	s.mu.Unlock()
+
+	for _, f := range notify {
+		f()
+	}
+}
+
+func (s *OverrideStore) get(nic string) (override, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.overrides[nic]
+	return o, ok
+}
@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/sysadmin/network_bandwidth_scheduler/throttle/fake"
+	"github.com/karagog/clock-go/simulated"
+)
+
+func TestOverrideForcesThrottlingOutsideSchedule(t *testing.T) {
+	o := fake.New()
+	clk := simulated.NewClock(time.Date(2023, 12, 30, 13, 0, 0, 0, time.UTC)) // outside the 0-12h window
+	rules := []Rule{{Weekdays: AllWeek, Start: 0, End: 12 * time.Hour, Profile: Profile{DownloadKbps: 1000, UploadKbps: 500}}}
+	s, err := New("foo", rules, o, clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overrides := NewOverrideStore()
+	s.SetOverrides(overrides)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	time.Sleep(20 * time.Millisecond) // let Run apply the initial (unlimited) state
+
+	overrides.Set("foo", true, "backup_running")
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-done
+
+	if o.ApplyCount == 0 {
+		t.Fatal("Applied throttling 0 times, want at least 1 after override")
+	}
+	if o.LastDownload != 1000 {
+		t.Fatalf("LastDownload = %v, want 1000 (the default profile)", o.LastDownload)
+	}
+}
+
+func TestOverrideForcesClearDuringScheduledWindow(t *testing.T) {
+	o := fake.New()
+	clk := simulated.NewClock(time.Date(2023, 12, 30, 1, 0, 0, 0, time.UTC)) // inside the 0-12h window
+	rules := []Rule{{Weekdays: AllWeek, Start: 0, End: 12 * time.Hour, Profile: Profile{DownloadKbps: 1000, UploadKbps: 500}}}
+	s, err := New("foo", rules, o, clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clearsBefore := o.ClearCount // New already cleared once
+	overrides := NewOverrideStore()
+	s.SetOverrides(overrides)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	overrides.Set("foo", false, "maintenance")
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-done
+
+	if o.ApplyCount == 0 {
+		t.Fatal("Applied throttling 0 times, want at least 1 on startup")
+	}
+	if got := o.ClearCount - clearsBefore; got < 2 {
+		t.Fatalf("Cleared throttling %d times after New, want at least 2: once after override, once on teardown", got)
+	}
+}
+
+func TestSetRulesTakesEffectImmediately(t *testing.T) {
+	o := fake.New()
+	clk := simulated.NewClock(time.Date(2023, 12, 30, 13, 0, 0, 0, time.UTC))
+	s, err := New("foo", nil, o, clk) // no rules: always unlimited
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	if err := s.SetRules([]Rule{{Weekdays: AllWeek, Start: 0, End: 0, Profile: Profile{DownloadKbps: 2000, UploadKbps: 1000}}}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-done
+
+	if o.LastDownload != 2000 {
+		t.Fatalf("LastDownload = %v, want 2000 after SetRules", o.LastDownload)
+	}
+}
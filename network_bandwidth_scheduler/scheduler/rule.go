@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// Weekday is a bitmask of one or more days of the week that a Rule applies to.
+type Weekday uint8
+
+const (
+	Sunday Weekday = 1 << iota
+	Monday
+	Tuesday
+	Wednesday
+	Thursday
+	Friday
+	Saturday
+
+	Weekdays = Monday | Tuesday | Wednesday | Thursday | Friday
+	Weekend  = Saturday | Sunday
+	AllWeek  = Weekdays | Weekend
+)
+
+func weekdayBit(d time.Weekday) Weekday {
+	return 1 << Weekday(d)
+}
+
+// Profile describes the bandwidth limits in effect while a Rule is active.
+type Profile struct {
+	// Unlimited means no throttling should be applied; the remaining fields are ignored
+	// in that case.
+	Unlimited bool
+
+	// DownloadKbps/UploadKbps are fixed caps, used unless the corresponding Adaptive*
+	// field below is set.
+	DownloadKbps int
+	UploadKbps   int
+
+	// AdaptiveDownload/AdaptiveUpload, if set, replace the fixed cap above with one that
+	// tracks recently measured throughput. They require the Scheduler to have been
+	// constructed with a throughput.Monitor for the nic.
+	AdaptiveDownload *AdaptiveProfile
+	AdaptiveUpload   *AdaptiveProfile
+}
+
+// Rule is a cron-like schedule entry: Profile is in effect on the days set in Weekdays,
+// between Start and End time-of-day. If Start == End the rule applies all day. If End is
+// before Start, the window wraps past midnight (e.g. Start=22h, End=6h means 10pm-6am).
+// When more than one Rule matches at a given instant, the one with the highest Priority
+// wins; ties are broken by whichever was declared first.
+type Rule struct {
+	Weekdays Weekday
+	Start    time.Duration
+	End      time.Duration
+	Profile  Profile
+	Priority int
+}
+
+func (r *Rule) validate() error {
+	if r.Weekdays == 0 {
+		return fmt.Errorf("rule has no weekdays set")
+	}
+	if r.Start < 0 || r.End < 0 || r.Start >= 24*time.Hour || r.End >= 24*time.Hour {
+		return fmt.Errorf("rule times must be at least 0 and less than 24 hours, got: %v, %v", r.Start, r.End)
+	}
+	return nil
+}
+
+// matches reports whether the rule is active at t. For windows that wrap past midnight,
+// the weekday check uses the day the window opened on, not t's own calendar day: the tail
+// of the window (the portion before End, after midnight) belongs to the previous day.
+func (r *Rule) matches(t time.Time) bool {
+	if r.Start == r.End {
+		return r.Weekdays&weekdayBit(t.Weekday()) != 0
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	tod := t.Sub(midnight)
+	if r.Start < r.End {
+		return r.Weekdays&weekdayBit(t.Weekday()) != 0 && tod >= r.Start && tod < r.End
+	}
+	if tod >= r.Start {
+		return r.Weekdays&weekdayBit(t.Weekday()) != 0
+	}
+	if tod < r.End {
+		prevWeekday := (t.Weekday() + 6) % 7
+		return r.Weekdays&weekdayBit(prevWeekday) != 0
+	}
+	return false
+}
+
+// resolveProfile returns the Profile that should be in effect at time t: the Profile of
+// the highest-Priority rule whose window contains t, or the unlimited Profile if none do.
+func resolveProfile(rules []Rule, t time.Time) Profile {
+	var best *Rule
+	for i := range rules {
+		r := &rules[i]
+		if r.matches(t) && (best == nil || r.Priority > best.Priority) {
+			best = r
+		}
+	}
+	if best == nil {
+		return Profile{Unlimited: true}
+	}
+	return best.Profile
+}
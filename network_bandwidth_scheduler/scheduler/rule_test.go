@@ -0,0 +1,123 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/karagog/clock-go/simulated"
+)
+
+func TestRuleMatches(t *testing.T) {
+	testCases := []struct {
+		desc string
+		rule Rule
+		t    time.Time
+		want bool
+	}{
+		{
+			desc: "within window",
+			rule: Rule{Weekdays: AllWeek, Start: 9 * time.Hour, End: 17 * time.Hour},
+			t:    time.Date(2023, 12, 30, 12, 0, 0, 0, time.UTC), // Saturday
+			want: true,
+		},
+		{
+			desc: "outside window",
+			rule: Rule{Weekdays: AllWeek, Start: 9 * time.Hour, End: 17 * time.Hour},
+			t:    time.Date(2023, 12, 30, 18, 0, 0, 0, time.UTC),
+			want: false,
+		},
+		{
+			desc: "wrong weekday",
+			rule: Rule{Weekdays: Weekdays, Start: 9 * time.Hour, End: 17 * time.Hour},
+			t:    time.Date(2023, 12, 30, 12, 0, 0, 0, time.UTC), // Saturday, not in Weekdays
+			want: false,
+		},
+		{
+			desc: "all day rule",
+			rule: Rule{Weekdays: AllWeek, Start: 0, End: 0},
+			t:    time.Date(2023, 12, 30, 23, 59, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			desc: "wraps past midnight, after start",
+			rule: Rule{Weekdays: AllWeek, Start: 22 * time.Hour, End: 6 * time.Hour},
+			t:    time.Date(2023, 12, 30, 23, 0, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			desc: "wraps past midnight, before end",
+			rule: Rule{Weekdays: AllWeek, Start: 22 * time.Hour, End: 6 * time.Hour},
+			t:    time.Date(2023, 12, 30, 1, 0, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			desc: "wraps past midnight, outside window",
+			rule: Rule{Weekdays: AllWeek, Start: 22 * time.Hour, End: 6 * time.Hour},
+			t:    time.Date(2023, 12, 30, 12, 0, 0, 0, time.UTC),
+			want: false,
+		},
+		{
+			desc: "wraps past midnight, single weekday, tail belongs to the window's start day",
+			rule: Rule{Weekdays: Friday, Start: 22 * time.Hour, End: 6 * time.Hour},
+			t:    time.Date(2023, 12, 30, 2, 0, 0, 0, time.UTC), // Saturday 2am, window opened Friday
+			want: true,
+		},
+		{
+			desc: "wraps past midnight, single weekday, tail does not belong to a different start day",
+			rule: Rule{Weekdays: Saturday, Start: 22 * time.Hour, End: 6 * time.Hour},
+			t:    time.Date(2023, 12, 30, 2, 0, 0, 0, time.UTC), // Saturday 2am, but the window since Friday isn't Saturday's
+			want: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := tc.rule.matches(tc.t); got != tc.want {
+				t.Fatalf("matches(%v) = %v, want %v", tc.t, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveProfilePriority(t *testing.T) {
+	low := Rule{Weekdays: AllWeek, Start: 0, End: 0, Priority: 0, Profile: Profile{DownloadKbps: 1000}}
+	high := Rule{Weekdays: AllWeek, Start: 0, End: 0, Priority: 1, Profile: Profile{DownloadKbps: 2000}}
+	got := resolveProfile([]Rule{low, high}, time.Date(2023, 12, 30, 12, 0, 0, 0, time.UTC))
+	if got != high.Profile {
+		t.Fatalf("resolveProfile() = %+v, want %+v", got, high.Profile)
+	}
+}
+
+func TestResolveProfileNoMatchIsUnlimited(t *testing.T) {
+	rule := Rule{Weekdays: AllWeek, Start: 9 * time.Hour, End: 10 * time.Hour, Profile: Profile{DownloadKbps: 1000}}
+	got := resolveProfile([]Rule{rule}, time.Date(2023, 12, 30, 12, 0, 0, 0, time.UTC))
+	if !got.Unlimited {
+		t.Fatalf("resolveProfile() = %+v, want Unlimited", got)
+	}
+}
+
+func TestComputeNextOccurrence(t *testing.T) {
+	rules := []Rule{
+		{Weekdays: AllWeek, Start: 9 * time.Hour, End: 17 * time.Hour, Profile: Profile{DownloadKbps: 1000}},
+	}
+	clk := simulated.NewClock(time.Date(2023, 12, 30, 1, 0, 0, 0, time.UTC))
+	gotTime, gotProfile := computeNextOccurrence(rules, clk)
+	wantTime := time.Date(2023, 12, 30, 9, 0, 0, 0, time.UTC)
+	if !gotTime.Equal(wantTime) {
+		t.Fatalf("computeNextOccurrence() time = %v, want %v", gotTime, wantTime)
+	}
+	if gotProfile != rules[0].Profile {
+		t.Fatalf("computeNextOccurrence() profile = %+v, want %+v", gotProfile, rules[0].Profile)
+	}
+}
+
+func TestComputeNextOccurrenceNoRules(t *testing.T) {
+	now := time.Date(2023, 12, 30, 1, 0, 0, 0, time.UTC)
+	clk := simulated.NewClock(now)
+	gotTime, gotProfile := computeNextOccurrence(nil, clk)
+	if !gotTime.Equal(now.Add(24 * time.Hour)) {
+		t.Fatalf("computeNextOccurrence() time = %v, want %v", gotTime, now.Add(24*time.Hour))
+	}
+	if !gotProfile.Unlimited {
+		t.Fatalf("computeNextOccurrence() profile = %+v, want Unlimited", gotProfile)
+	}
+}
@@ -2,112 +2,406 @@ package scheduler
 
 import (
 	"context"
-	"flag"
-	"fmt"
+	"errors"
 	"log"
-	"os/exec"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"example.com/sysadmin/network_bandwidth_scheduler/throttle"
+	"example.com/sysadmin/network_bandwidth_scheduler/throughput"
 	"github.com/karagog/clock-go"
 )
 
-var wondershaperPath = flag.String("wondershaper_path", "/usr/local/sbin/wondershaper", "The path to 'wondershaper'")
-var downloadKbps = flag.Int("download_kbps", 10000, "Throttle the download rate to this value.")
-var uploadKbps = flag.Int("upload_kbps", 10000, "Throttle the upload rate to this value.")
+// ErrAlreadyStarted is returned by Start if the Scheduler is already running.
+var ErrAlreadyStarted = errors.New("scheduler: already started")
 
-var applyThrottling = func(nic string) {
-	cmd := exec.Command(*wondershaperPath, "-a", nic, "-d", fmt.Sprintf("%d", *downloadKbps), "-u", fmt.Sprintf("%d", *uploadKbps))
-	if err := cmd.Run(); err != nil {
-		log.Printf("Error executing wondershaper: %s\n", err)
-		return
-	}
-	log.Printf("Now throttling interface '%s' to %d Kbps download and %d Kbps upload\n", nic, *downloadKbps, *uploadKbps)
-}
+// ErrAlreadyStopped is returned by Stop if the Scheduler isn't running.
+var ErrAlreadyStopped = errors.New("scheduler: already stopped")
 
-var clearThrottling = func(nic string) {
-	cmd := exec.Command(*wondershaperPath, "-c", "-a", nic)
-	// Ignore the error, because the latest version of wondershaper always returns non-zero even if this was successful.
-	cmd.Run()
-	log.Printf("Removed throttling on interface '%s'\n", nic)
-}
+// defaultAdaptiveCheckInterval is how often an adaptive cap is re-evaluated against
+// recently measured throughput, if SetMonitor isn't given an explicit interval.
+const defaultAdaptiveCheckInterval = 30 * time.Second
+
+// lookaheadDays bounds how far into the future computeNextOccurrence scans for rule
+// transitions. A week plus one day is enough to find the next transition for any rule,
+// since every rule recurs at least once a week.
+const lookaheadDays = 8
 
-// This assumes the given duration is greater than 0 and less than 24 hours.
-func computeNextOccurrence(timeOfDay time.Duration, clk clock.Clock) time.Time {
+// computeNextOccurrence finds the next time any rule's window opens or closes after
+// clk.Now(), and the Profile that should be in effect once it does. If no rule ever
+// transitions (e.g. the configuration has no rules, or only all-day ones covering every
+// day of the week), it reports back in 24 hours so callers notice a config reload.
+func computeNextOccurrence(rules []Rule, clk clock.Clock) (time.Time, Profile) {
 	now := clk.Now()
-	t := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Add(timeOfDay)
-	if t.Before(now) {
-		t = t.Add(24 * time.Hour)
+	var candidates []time.Time
+	// Start from yesterday: a window opened then may wrap past midnight and close today.
+	for offset := -1; offset < lookaheadDays; offset++ {
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, offset)
+		for _, r := range rules {
+			if r.Weekdays&weekdayBit(day.Weekday()) == 0 {
+				continue
+			}
+			if r.Start == r.End {
+				// An all-day window opens at midnight unless the day before is also covered,
+				// and closes at the next midnight unless the day after is.
+				next := day.AddDate(0, 0, 1)
+				if r.Weekdays&weekdayBit(day.AddDate(0, 0, -1).Weekday()) == 0 && day.After(now) {
+					candidates = append(candidates, day)
+				}
+				if r.Weekdays&weekdayBit(next.Weekday()) == 0 && next.After(now) {
+					candidates = append(candidates, next)
+				}
+				continue
+			}
+			start, end := day.Add(r.Start), day.Add(r.End)
+			if r.End < r.Start {
+				end = end.AddDate(0, 0, 1)
+			}
+			if start.After(now) {
+				candidates = append(candidates, start)
+			}
+			if end.After(now) {
+				candidates = append(candidates, end)
+			}
+		}
 	}
-	return t
+	if len(candidates) == 0 {
+		t := now.Add(24 * time.Hour)
+		return t, resolveProfile(rules, t)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	t := candidates[0]
+	return t, resolveProfile(rules, t)
 }
 
+// Scheduler applies a schedule of Rules to a single network interface, switching between
+// throttling Profiles as rule windows open and close.
 type Scheduler struct {
-	nic                                        string
-	clock                                      clock.Clock
-	nextThrottleStartTime, nextThrottleEndTime time.Time
-	nextUpdateTime                             time.Time
-	throttling                                 bool
+	nic       string
+	clock     clock.Clock
+	throttler throttle.Throttler
+
+	mu             sync.Mutex // guards rules
+	rules          []Rule
+	defaultProfile Profile // used when an override forces throttling on outside any rule
+
+	monitor          *throughput.Monitor
+	adaptiveInterval time.Duration
+	cancelAdaptive   context.CancelFunc
+
+	overrides  *OverrideStore
+	reevaluate chan struct{}
+
+	lifecycle sync.Mutex // guards cancel and done
+	running   atomic.Bool
+	cancel    context.CancelFunc
+	done      chan struct{}
 }
 
-func New(nic string, throttleTimeStart, throttleTimeEnd time.Duration, clock clock.Clock) (*Scheduler, error) {
-	if throttleTimeStart < 0 || throttleTimeEnd < 0 ||
-		throttleTimeStart >= 24*time.Hour || throttleTimeEnd >= 24*time.Hour {
-		return nil, fmt.Errorf("times must be greater than 0 and less than 24 hours, got: %v, %v", throttleTimeStart, throttleTimeEnd)
+// New creates a Scheduler that enforces rules on nic using throttler.
+func New(nic string, rules []Rule, throttler throttle.Throttler, clock clock.Clock) (*Scheduler, error) {
+	if err := validateRules(rules); err != nil {
+		return nil, err
 	}
 
 	// Clear any previous throttling that may be on this nic. If we don't, then the new
 	// settings won't be applied.
-	clearThrottling(nic)
+	if err := throttler.Clear(context.Background(), nic); err != nil {
+		log.Printf("Error clearing throttling via %s: %v\n", throttler.Name(), err)
+	}
 
 	return &Scheduler{
-		nic:                   nic,
-		clock:                 clock,
-		nextThrottleStartTime: computeNextOccurrence(throttleTimeStart, clock),
-		nextThrottleEndTime:   computeNextOccurrence(throttleTimeEnd, clock),
+		nic:            nic,
+		clock:          clock,
+		throttler:      throttler,
+		rules:          rules,
+		defaultProfile: defaultProfileFor(rules),
+		reevaluate:     make(chan struct{}, 1),
 	}, nil
 }
 
+func validateRules(rules []Rule) error {
+	for _, r := range rules {
+		if err := r.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAdaptiveRules reports an error if any rule's Profile uses AdaptiveDownload or
+// AdaptiveUpload but monitor is nil, so that's caught at Start/SetRules time rather than
+// panicking the first time runAdaptive dereferences a nil monitor.
+func validateAdaptiveRules(rules []Rule, monitor *throughput.Monitor) error {
+	if monitor != nil {
+		return nil
+	}
+	for _, r := range rules {
+		if r.Profile.AdaptiveDownload != nil || r.Profile.AdaptiveUpload != nil {
+			return errors.New("scheduler: rule uses an adaptive profile but no throughput.Monitor is attached; call SetMonitor before Start")
+		}
+	}
+	return nil
+}
+
+// defaultProfileFor returns the Profile of the first Rule that isn't Unlimited, for use
+// when an override forces throttling on outside of any scheduled window.
+func defaultProfileFor(rules []Rule) Profile {
+	for _, r := range rules {
+		if !r.Profile.Unlimited {
+			return r.Profile
+		}
+	}
+	return Profile{Unlimited: true}
+}
+
+// SetMonitor attaches a throughput.Monitor to the Scheduler, required for any Rule whose
+// Profile uses AdaptiveDownload/AdaptiveUpload. checkInterval controls how often the
+// applied cap is re-evaluated against recently measured throughput; if zero or negative,
+// defaultAdaptiveCheckInterval is used. It must be called before Run.
+func (s *Scheduler) SetMonitor(monitor *throughput.Monitor, checkInterval time.Duration) {
+	if checkInterval <= 0 {
+		checkInterval = defaultAdaptiveCheckInterval
+	}
+	s.monitor = monitor
+	s.adaptiveInterval = checkInterval
+}
+
+// SetOverrides attaches an OverrideStore to the Scheduler: overrides on s.nic take
+// priority over the schedule, and are consulted before every applied profile. It must be
+// called before Run.
+func (s *Scheduler) SetOverrides(store *OverrideStore) {
+	s.overrides = store
+	store.Subscribe(s.nic, s.Reevaluate)
+}
+
+// SetRules replaces the schedule's rules, e.g. after a config reload. It takes effect
+// immediately, without waiting for Run's next scheduled wakeup.
+func (s *Scheduler) SetRules(rules []Rule) error {
+	if err := validateRules(rules); err != nil {
+		return err
+	}
+	if err := validateAdaptiveRules(rules, s.monitor); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.rules = rules
+	s.defaultProfile = defaultProfileFor(rules)
+	s.mu.Unlock()
+	s.Reevaluate()
+	return nil
+}
+
+func (s *Scheduler) getRules() []Rule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rules
+}
+
+// Reevaluate asks Run to immediately recheck overrides and the schedule, instead of
+// waiting for the next scheduled transition. It's safe to call concurrently and from any
+// goroutine, including before Run has started.
+func (s *Scheduler) Reevaluate() {
+	select {
+	case s.reevaluate <- struct{}{}:
+	default:
+	}
+}
+
+// currentCheck decides whether nic should be throttled at t: an override, if any is set,
+// always wins over the schedule.
+func (s *Scheduler) currentCheck(t time.Time) ThrottleCheckResult {
+	if s.overrides != nil {
+		if o, ok := s.overrides.get(s.nic); ok {
+			if o.shouldThrottle {
+				return ThrottleCheckResult{ShouldThrottle: true, Profile: s.defaultProfile, Reason: "override", ReasonHint: o.reason}
+			}
+			return ThrottleCheckResult{ShouldThrottle: false, Profile: Profile{Unlimited: true}, Reason: "override", ReasonHint: o.reason}
+		}
+	}
+	p := resolveProfile(s.getRules(), t)
+	return ThrottleCheckResult{ShouldThrottle: !p.Unlimited, Profile: p, Reason: "schedule"}
+}
+
+// Start runs the Scheduler's loop in a background goroutine and returns immediately. It
+// returns ErrAlreadyStarted if the Scheduler is already running. Callers that want the
+// schedule to stop must call Stop; there's no parent context to cancel.
+func (s *Scheduler) Start() error {
+	s.lifecycle.Lock()
+	defer s.lifecycle.Unlock()
+	if s.running.Load() {
+		return ErrAlreadyStarted
+	}
+	if err := validateAdaptiveRules(s.getRules(), s.monitor); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.cancel = cancel
+	s.done = done
+	s.running.Store(true)
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	return nil
+}
+
+// Stop cancels the Scheduler's loop and blocks until it has torn down its throttling. It
+// returns ErrAlreadyStopped if the Scheduler isn't running. A Scheduler may be Start'd
+// again afterward. Stop holds the lifecycle lock for the duration of teardown, so a
+// concurrent Start waits for the old Run goroutine to fully exit before starting a new one.
+func (s *Scheduler) Stop() error {
+	s.lifecycle.Lock()
+	defer s.lifecycle.Unlock()
+	if !s.running.Load() {
+		return ErrAlreadyStopped
+	}
+	cancel, done := s.cancel, s.done
+	cancel()
+	<-done
+	s.running.Store(false)
+	return nil
+}
+
+// IsRunning reports whether the Scheduler was Start'd and hasn't been Stop'd since.
+func (s *Scheduler) IsRunning() bool {
+	return s.running.Load()
+}
+
+// Wait blocks until a Started Scheduler's loop exits, e.g. after a later Stop call. It
+// returns immediately if the Scheduler was never started.
+func (s *Scheduler) Wait() {
+	s.lifecycle.Lock()
+	done := s.done
+	s.lifecycle.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
 func (s *Scheduler) Run(ctx context.Context) {
-	// Update throttling for the first time on startup.
-	if s.nextThrottleStartTime == s.nextThrottleEndTime {
-		applyThrottling(s.nic)
-		return // exit gracefully since we want to throttle ad infinitum
-	} else if s.nextThrottleEndTime.Before(s.nextThrottleStartTime) {
-		applyThrottling(s.nic)
-		s.nextUpdateTime = s.nextThrottleEndTime
-		s.throttling = true
-	} else {
-		clearThrottling(s.nic)
-		s.nextUpdateTime = s.nextThrottleStartTime
-		s.throttling = false
-	}
-
-	// Loop forever and update the throttling when necessary.
+	// Run is reachable directly, bypassing Start's validateAdaptiveRules check, so check
+	// again here: otherwise a rule with an adaptive profile and no monitor attached would
+	// panic the first time runAdaptive dereferences a nil s.monitor.
+	if err := validateAdaptiveRules(s.getRules(), s.monitor); err != nil {
+		log.Printf("nic '%s': %v\n", s.nic, err)
+		return
+	}
+
+	// Apply whatever's in effect right now on startup.
+	s.applyCheck(ctx, s.currentCheck(s.clock.Now()))
+
+	// Loop forever, re-checking whenever a rule's window transitions or an override
+	// changes.
+	for {
+		nextTime, _ := computeNextOccurrence(s.getRules(), s.clock)
+		t := s.clock.NewTimer(nextTime.Sub(s.clock.Now()))
+		select {
+		case <-t.C():
+			t.Stop()
+			s.applyCheck(ctx, s.currentCheck(s.clock.Now()))
+		case <-s.reevaluate:
+			t.Stop()
+			s.applyCheck(ctx, s.currentCheck(s.clock.Now()))
+		case <-ctx.Done():
+			t.Stop()
+			// Clear throttling on service exit. ctx is already canceled here, and the
+			// exec-based throttlers won't start a command under a canceled context.
+			s.clearThrottling(context.Background())
+			return
+		}
+	}
+}
+
+func (s *Scheduler) applyCheck(ctx context.Context, check ThrottleCheckResult) {
+	log.Printf("nic '%s': throttle=%v reason=%s hint=%q\n", s.nic, check.ShouldThrottle, check.Reason, check.ReasonHint)
+	s.applyProfile(ctx, check.Profile)
+}
+
+// Status reports the Scheduler's current state for nic, for the admin /status endpoint.
+func (s *Scheduler) Status() Status {
+	now := s.clock.Now()
+	check := s.currentCheck(now)
+	nextTime, _ := computeNextOccurrence(s.getRules(), s.clock)
+	return Status{
+		Nic:            s.nic,
+		Throttling:     check.ShouldThrottle,
+		DownloadKbps:   check.Profile.DownloadKbps,
+		UploadKbps:     check.Profile.UploadKbps,
+		Reason:         check.Reason,
+		ReasonHint:     check.ReasonHint,
+		NextTransition: nextTime,
+	}
+}
+
+func (s *Scheduler) applyProfile(ctx context.Context, p Profile) {
+	if s.cancelAdaptive != nil {
+		s.cancelAdaptive()
+		s.cancelAdaptive = nil
+	}
+
+	if p.Unlimited {
+		s.clearThrottling(ctx)
+		return
+	}
+
+	if p.AdaptiveDownload != nil || p.AdaptiveUpload != nil {
+		adaptiveCtx, cancel := context.WithCancel(ctx)
+		s.cancelAdaptive = cancel
+		go s.runAdaptive(adaptiveCtx, p)
+		return
+	}
+
+	s.applyCaps(ctx, p.DownloadKbps, p.UploadKbps)
+}
+
+// runAdaptive re-evaluates p's adaptive caps every s.adaptiveInterval, based on throughput
+// measured by s.monitor during the prior interval, until ctx is done.
+func (s *Scheduler) runAdaptive(ctx context.Context, p Profile) {
+	// No throughput has been sampled yet, so there's nothing to target a fraction of.
+	// Bootstrap from each adaptive profile's floor instead of the fixed Kbps fields, which
+	// are left at their zero value by a config that sets only the adaptive field.
+	down, up := p.DownloadKbps, p.UploadKbps
+	if p.AdaptiveDownload != nil {
+		down = p.AdaptiveDownload.MinKbps
+	}
+	if p.AdaptiveUpload != nil {
+		up = p.AdaptiveUpload.MinKbps
+	}
+	s.applyCaps(ctx, down, up)
+
 	for {
-		now := s.clock.Now()
-		t := s.clock.NewTimer(s.nextUpdateTime.Sub(now))
+		t := s.clock.NewTimer(s.adaptiveInterval)
 		select {
 		case <-t.C():
 			t.Stop()
-			s.toggleBandwidthEnforcement()
+			now := s.clock.Now()
+			from := now.Add(-s.adaptiveInterval)
+			if p.AdaptiveDownload != nil {
+				down = p.AdaptiveDownload.nextCap(s.monitor.RxKbps(from, now), down)
+			}
+			if p.AdaptiveUpload != nil {
+				up = p.AdaptiveUpload.nextCap(s.monitor.TxKbps(from, now), up)
+			}
+			s.applyCaps(ctx, down, up)
 		case <-ctx.Done():
-			// Clear throttling on service exit.
-			clearThrottling(s.nic)
+			t.Stop()
 			return
 		}
 	}
 }
 
-func (s *Scheduler) toggleBandwidthEnforcement() {
-	if s.throttling {
-		clearThrottling(s.nic)
-		s.throttling = false
-		s.nextThrottleEndTime = s.nextThrottleEndTime.Add(24 * time.Hour)
-		s.nextUpdateTime = s.nextThrottleStartTime
-	} else {
-		applyThrottling(s.nic)
-		s.throttling = true
-		s.nextThrottleStartTime = s.nextThrottleStartTime.Add(24 * time.Hour)
-		s.nextUpdateTime = s.nextThrottleEndTime
+func (s *Scheduler) applyCaps(ctx context.Context, downloadKbps, uploadKbps int) {
+	if err := s.throttler.Apply(ctx, s.nic, downloadKbps, uploadKbps); err != nil {
+		log.Printf("Error applying throttling via %s: %v\n", s.throttler.Name(), err)
+	}
+}
+
+func (s *Scheduler) clearThrottling(ctx context.Context) {
+	if err := s.throttler.Clear(ctx, s.nic); err != nil {
+		log.Printf("Error clearing throttling via %s: %v\n", s.throttler.Name(), err)
 	}
 }
@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/sysadmin/network_bandwidth_scheduler/throttle/fake"
+	"example.com/sysadmin/network_bandwidth_scheduler/throughput"
+	"github.com/karagog/clock-go/simulated"
+)
+
+// constantSampler reports a fixed, steadily increasing byte counter, so Monitor observes
+// a constant throughput.
+type constantSampler struct {
+	rxBytesPerSec, txBytesPerSec uint64
+	elapsed                      uint64
+}
+
+func (s *constantSampler) Sample(nic string) (uint64, uint64, error) {
+	s.elapsed++
+	return s.rxBytesPerSec * s.elapsed, s.txBytesPerSec * s.elapsed, nil
+}
+
+func TestSchedulerAdaptiveProfile(t *testing.T) {
+	clk := simulated.NewClock(time.Date(2023, 12, 30, 0, 0, 0, 0, time.UTC))
+	sampler := &constantSampler{rxBytesPerSec: 1_000_000} // 8,000 kbps
+	monitor := throughput.NewMonitor("foo", sampler, clk, 10*time.Second)
+
+	rules := []Rule{{
+		Weekdays: AllWeek, Start: 0, End: 0,
+		Profile: Profile{AdaptiveDownload: &AdaptiveProfile{TargetUtilization: 0.5, MinKbps: 100, MaxKbps: 100000, Hysteresis: 0.1}},
+	}}
+	o := fake.New()
+	s, err := New("foo", rules, o, clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetMonitor(monitor, time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	monitorDone := make(chan struct{})
+	go func() {
+		defer close(monitorDone)
+		monitor.Run(ctx, time.Second)
+	}()
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		s.Run(ctx)
+	}()
+
+	for i := 0; i < 5; i++ {
+		time.Sleep(5 * time.Millisecond)
+		clk.Advance(time.Second)
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	<-schedulerDone
+	<-monitorDone
+
+	if o.ApplyCount == 0 {
+		t.Fatal("Applied throttling 0 times, want at least 1")
+	}
+	if o.LastDownload == 0 {
+		t.Fatalf("LastDownload = %v, want a positive adaptive cap", o.LastDownload)
+	}
+}
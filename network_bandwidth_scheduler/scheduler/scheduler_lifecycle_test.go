@@ -0,0 +1,147 @@
+package scheduler
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"example.com/sysadmin/network_bandwidth_scheduler/throttle/fake"
+	"github.com/karagog/clock-go/simulated"
+)
+
+func TestStartStop(t *testing.T) {
+	o := fake.New()
+	clk := simulated.NewClock(time.Date(2023, 12, 30, 1, 0, 0, 0, time.UTC))
+	rules := []Rule{{Weekdays: AllWeek, Start: 0, End: 12 * time.Hour, Profile: Profile{DownloadKbps: 1000, UploadKbps: 1000}}}
+	s, err := New("foo", rules, o, clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clearsBefore := o.ClearCount // New already cleared once
+	if s.IsRunning() {
+		t.Fatal("IsRunning() = true before Start")
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsRunning() {
+		t.Fatal("IsRunning() = false after Start")
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if s.IsRunning() {
+		t.Fatal("IsRunning() = true after Stop")
+	}
+	if o.ClearCount == clearsBefore {
+		t.Fatal("ClearCount unchanged by Stop, want at least 1 more after Stop tears down throttling")
+	}
+}
+
+func TestStartTwiceReturnsErrAlreadyStarted(t *testing.T) {
+	clk := simulated.NewClock(time.Now())
+	s, err := New("foo", nil, fake.New(), clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	if err := s.Start(); err != ErrAlreadyStarted {
+		t.Fatalf("Start() = %v, want ErrAlreadyStarted", err)
+	}
+}
+
+func TestStopTwiceReturnsErrAlreadyStopped(t *testing.T) {
+	clk := simulated.NewClock(time.Now())
+	s, err := New("foo", nil, fake.New(), clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Stop(); err != ErrAlreadyStopped {
+		t.Fatalf("Stop() = %v, want ErrAlreadyStopped", err)
+	}
+}
+
+func TestStopBeforeStartReturnsErrAlreadyStopped(t *testing.T) {
+	clk := simulated.NewClock(time.Now())
+	s, err := New("foo", nil, fake.New(), clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Stop(); err != ErrAlreadyStopped {
+		t.Fatalf("Stop() = %v, want ErrAlreadyStopped", err)
+	}
+}
+
+func TestConcurrentStartStop(t *testing.T) {
+	o := fake.New()
+	clk := simulated.NewClock(time.Date(2023, 12, 30, 1, 0, 0, 0, time.UTC))
+	rules := []Rule{{Weekdays: AllWeek, Start: 0, End: 12 * time.Hour, Profile: Profile{DownloadKbps: 1000, UploadKbps: 1000}}}
+	s, err := New("foo", rules, o, clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hammer Start and Stop from separate goroutines with no external synchronization:
+	// Stop must finish tearing down the old Run goroutine before a racing Start is
+	// allowed to launch a new one, so this must never panic or report a data race
+	// (run with -race) regardless of scheduling order.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			s.Start()
+		}()
+		go func() {
+			defer wg.Done()
+			s.Stop()
+		}()
+	}
+	wg.Wait()
+
+	// Leave the scheduler in a known state regardless of how the race above resolved.
+	if s.IsRunning() {
+		s.Stop()
+	}
+}
+
+func TestWaitReturnsAfterStop(t *testing.T) {
+	clk := simulated.NewClock(time.Now())
+	s, err := New("foo", nil, fake.New(), clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	waitDone := make(chan struct{})
+	go func() {
+		defer close(waitDone)
+		s.Wait()
+	}()
+
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-waitDone:
+	case <-time.After(time.Second):
+		t.Fatal("Wait() did not return after Stop")
+	}
+}
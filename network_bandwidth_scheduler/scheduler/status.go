@@ -0,0 +1,14 @@
+package scheduler
+
+import "time"
+
+// Status is a Scheduler's current state, as reported by the admin /status endpoint.
+type Status struct {
+	Nic            string    `json:"nic"`
+	Throttling     bool      `json:"throttling"`
+	DownloadKbps   int       `json:"download_kbps"`
+	UploadKbps     int       `json:"upload_kbps"`
+	Reason         string    `json:"reason"`
+	ReasonHint     string    `json:"reason_hint,omitempty"`
+	NextTransition time.Time `json:"next_transition"`
+}
@@ -0,0 +1,44 @@
+// Package fake provides an in-memory throttle.Throttler for tests, replacing the old
+// pattern of monkey-patching package-level function variables.
+package fake
+
+import (
+	"context"
+	"sync"
+)
+
+// Throttler records Apply/Clear calls instead of touching the host.
+type Throttler struct {
+	mu                   sync.Mutex
+	ApplyCount           int
+	ClearCount           int
+	LastNic              string
+	LastDownload, LastUp int
+}
+
+// New returns a ready-to-use fake Throttler.
+func New() *Throttler {
+	return &Throttler{}
+}
+
+func (t *Throttler) Apply(ctx context.Context, nic string, downloadKbps, uploadKbps int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ApplyCount++
+	t.LastNic = nic
+	t.LastDownload = downloadKbps
+	t.LastUp = uploadKbps
+	return nil
+}
+
+func (t *Throttler) Clear(ctx context.Context, nic string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ClearCount++
+	t.LastNic = nic
+	return nil
+}
+
+func (t *Throttler) Name() string {
+	return "fake"
+}
@@ -0,0 +1,77 @@
+// Package nftables implements throttle.Throttler on top of nftables traffic policing,
+// for kernels where nftables is preferred over the legacy tc/htb stack.
+//
+// This is a policer, not a shaper: nftables has no queuing discipline of its own, so
+// traffic over the configured rate is dropped outright rather than queued and smoothed
+// out like the tc and wondershaper backends do with HTB. Expect burstier, lossier
+// behavior at the limit compared to those backends, which is a reasonable tradeoff for
+// simplicity but worth knowing before picking --backend=nftables for latency-sensitive
+// traffic.
+package nftables
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+)
+
+const tableName = "network_bandwidth_scheduler"
+
+// Throttler drives 'nft' to install and remove a per-nic policing table.
+type Throttler struct {
+	// Path is the path to the 'nft' executable.
+	Path string
+}
+
+// New returns a Throttler that invokes nft at path.
+func New(path string) *Throttler {
+	return &Throttler{Path: path}
+}
+
+func (t *Throttler) Apply(ctx context.Context, nic string, downloadKbps, uploadKbps int) error {
+	if err := t.Clear(ctx, nic); err != nil {
+		return fmt.Errorf("clearing previous nftables config: %w", err)
+	}
+
+	table := tableFor(nic)
+	script := fmt.Sprintf(`
+table inet %[1]s {
+	chain input {
+		type filter hook input priority 0; policy accept;
+		iifname "%[2]s" limit rate over %[3]d kbytes/second drop
+	}
+	chain output {
+		type filter hook output priority 0; policy accept;
+		oifname "%[2]s" limit rate over %[4]d kbytes/second drop
+	}
+}
+`, table, nic, downloadKbps/8, uploadKbps/8)
+
+	cmd := exec.CommandContext(ctx, t.Path, "-f", "-")
+	cmd.Stdin = strings.NewReader(script)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("loading nftables ruleset: %w", err)
+	}
+
+	log.Printf("Now throttling interface '%s' to %d Kbps download and %d Kbps upload\n", nic, downloadKbps, uploadKbps)
+	return nil
+}
+
+func (t *Throttler) Clear(ctx context.Context, nic string) error {
+	cmd := exec.CommandContext(ctx, t.Path, "delete", "table", "inet", tableFor(nic))
+	// Ignore the error: it returns non-zero when the table doesn't exist, which is the
+	// common case.
+	cmd.Run()
+	log.Printf("Removed throttling on interface '%s'\n", nic)
+	return nil
+}
+
+func (t *Throttler) Name() string {
+	return "nftables"
+}
+
+func tableFor(nic string) string {
+	return fmt.Sprintf("%s_%s", tableName, nic)
+}
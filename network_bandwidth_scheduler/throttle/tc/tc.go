@@ -0,0 +1,121 @@
+// Package tc implements throttle.Throttler directly on top of the Linux 'tc' (traffic
+// control) command, using an HTB qdisc. This avoids a dependency on the wondershaper
+// shell script.
+package tc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+)
+
+// ifNameSizeMax is Linux's IFNAMSIZ (16) minus the trailing NUL: the longest name a
+// network interface, including an IFB device, can have.
+const ifNameSizeMax = 15
+
+// ifbFor returns the name of the IFB device nic's ingress traffic is redirected onto, so
+// that multiple interfaces sharing a Throttler (see scheduler.ScheduleManager) each get
+// their own IFB instead of clobbering one shared device. The name is cut to
+// ifNameSizeMax, so two nics whose names share their first 11 characters share an IFB.
+func ifbFor(nic string) string {
+	name := "ifb-" + nic
+	if len(name) > ifNameSizeMax {
+		name = name[:ifNameSizeMax]
+	}
+	return name
+}
+
+// Throttler drives 'tc' directly, setting up an HTB qdisc for egress and redirecting
+// ingress traffic through an IFB device so it can be shaped the same way.
+type Throttler struct {
+	// Path is the path to the 'tc' executable.
+	Path string
+
+	// IPPath is the path to the 'ip' executable, used to create and bring up the IFB
+	// device that ingress shaping redirects onto.
+	IPPath string
+}
+
+// New returns a Throttler that invokes tc at tcPath and ip at ipPath.
+func New(tcPath, ipPath string) *Throttler {
+	return &Throttler{Path: tcPath, IPPath: ipPath}
+}
+
+func (t *Throttler) Apply(ctx context.Context, nic string, downloadKbps, uploadKbps int) error {
+	if err := t.Clear(ctx, nic); err != nil {
+		return fmt.Errorf("clearing previous tc config: %w", err)
+	}
+
+	// Egress (upload) shaping directly on the nic.
+	for _, args := range [][]string{
+		{"qdisc", "add", "dev", nic, "root", "handle", "1:", "htb", "default", "10"},
+		{"class", "add", "dev", nic, "parent", "1:", "classid", "1:10", "htb", "rate", fmt.Sprintf("%dkbit", uploadKbps)},
+	} {
+		if err := t.run(ctx, args...); err != nil {
+			return err
+		}
+	}
+
+	// Ingress (download) shaping via a per-nic IFB device, since tc can't shape ingress
+	// directly. The device doesn't exist on a fresh host, so bring it up before tc can
+	// queue onto it.
+	ifb := ifbFor(nic)
+	if err := t.ensureIngressIfb(ctx, ifb); err != nil {
+		return fmt.Errorf("bringing up ingress IFB device %q: %w", ifb, err)
+	}
+	for _, args := range [][]string{
+		{"qdisc", "add", "dev", nic, "ingress"},
+		{"qdisc", "add", "dev", ifb, "root", "handle", "1:", "htb", "default", "10"},
+		{"class", "add", "dev", ifb, "parent", "1:", "classid", "1:10", "htb", "rate", fmt.Sprintf("%dkbit", downloadKbps)},
+		{"filter", "add", "dev", nic, "parent", "ffff:", "protocol", "ip", "u32", "match", "u32", "0", "0", "action", "mirred", "egress", "redirect", "dev", ifb},
+	} {
+		if err := t.run(ctx, args...); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Now throttling interface '%s' to %d Kbps download and %d Kbps upload\n", nic, downloadKbps, uploadKbps)
+	return nil
+}
+
+// ensureIngressIfb creates ifb and brings it up if it isn't already. Creation is allowed
+// to fail (it returns non-zero if the device already exists, which is the common case
+// after the first Apply), but bringing it up must succeed, since that's what tells us the
+// device is actually usable.
+func (t *Throttler) ensureIngressIfb(ctx context.Context, ifb string) error {
+	t.runIP(ctx, "link", "add", ifb, "type", "ifb")
+	return t.runIP(ctx, "link", "set", ifb, "up")
+}
+
+func (t *Throttler) Clear(ctx context.Context, nic string) error {
+	ifb := ifbFor(nic)
+	// Ignore errors: these return non-zero when there's nothing to remove, which is the
+	// common case.
+	t.run(ctx, "qdisc", "del", "dev", nic, "root")
+	t.run(ctx, "qdisc", "del", "dev", nic, "ingress")
+	t.run(ctx, "qdisc", "del", "dev", ifb, "root")
+	t.runIP(ctx, "link", "delete", ifb, "type", "ifb")
+	log.Printf("Removed throttling on interface '%s'\n", nic)
+	return nil
+}
+
+func (t *Throttler) Name() string {
+	return "tc"
+}
+
+func (t *Throttler) run(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, t.Path, args...)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("tc %v: %w", args, err)
+	}
+	return nil
+}
+
+func (t *Throttler) runIP(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, t.IPPath, args...)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ip %v: %w", args, err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package tc
+
+import "testing"
+
+func TestIfbForIsUniquePerNic(t *testing.T) {
+	eth0, eth1 := ifbFor("eth0"), ifbFor("eth1")
+	if eth0 == eth1 {
+		t.Fatalf("ifbFor returned the same device %q for both eth0 and eth1; a shared Throttler (scheduler.ScheduleManager) would clobber one nic's ingress shaping with the other's", eth0)
+	}
+}
+
+func TestIfbForTruncatesToIfNameSize(t *testing.T) {
+	got := ifbFor("a-very-long-interface-name")
+	if len(got) > ifNameSizeMax {
+		t.Fatalf("ifbFor(%q) = %q, length %d exceeds IFNAMSIZ-1 (%d)", "a-very-long-interface-name", got, len(got), ifNameSizeMax)
+	}
+}
@@ -0,0 +1,21 @@
+// Package throttle defines the interface the scheduler uses to enforce bandwidth
+// limits on a network interface, independent of whatever tool actually does the
+// enforcement on the host.
+package throttle
+
+import "context"
+
+// Throttler applies and clears bandwidth limits on a network interface. Implementations
+// wrap whatever host mechanism (wondershaper, tc, nftables, ...) actually shapes traffic.
+type Throttler interface {
+	// Apply limits the given nic to downloadKbps/uploadKbps. It may be called again on an
+	// already-throttled nic to change the limits.
+	Apply(ctx context.Context, nic string, downloadKbps, uploadKbps int) error
+
+	// Clear removes any throttling previously applied to the nic. It must be safe to call
+	// on a nic that isn't currently throttled.
+	Clear(ctx context.Context, nic string) error
+
+	// Name identifies the implementation, for logging.
+	Name() string
+}
@@ -0,0 +1,43 @@
+// Package wondershaper implements throttle.Throttler by shelling out to the
+// 'wondershaper' script.
+package wondershaper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+)
+
+// Throttler shells out to 'wondershaper' to apply and clear throttling.
+type Throttler struct {
+	// Path is the path to the 'wondershaper' executable.
+	Path string
+}
+
+// New returns a Throttler that invokes wondershaper at path.
+func New(path string) *Throttler {
+	return &Throttler{Path: path}
+}
+
+func (t *Throttler) Apply(ctx context.Context, nic string, downloadKbps, uploadKbps int) error {
+	cmd := exec.CommandContext(ctx, t.Path, "-a", nic, "-d", fmt.Sprintf("%d", downloadKbps), "-u", fmt.Sprintf("%d", uploadKbps))
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("executing wondershaper: %w", err)
+	}
+	log.Printf("Now throttling interface '%s' to %d Kbps download and %d Kbps upload\n", nic, downloadKbps, uploadKbps)
+	return nil
+}
+
+func (t *Throttler) Clear(ctx context.Context, nic string) error {
+	cmd := exec.CommandContext(ctx, t.Path, "-c", "-a", nic)
+	// Ignore the error, because the latest version of wondershaper always returns non-zero
+	// even if this was successful.
+	cmd.Run()
+	log.Printf("Removed throttling on interface '%s'\n", nic)
+	return nil
+}
+
+func (t *Throttler) Name() string {
+	return "wondershaper"
+}
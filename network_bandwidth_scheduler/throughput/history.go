@@ -0,0 +1,84 @@
+package throughput
+
+import (
+	"sync"
+	"time"
+)
+
+// record is one sample of a cumulative byte counter at a point in time.
+type record struct {
+	timestamp time.Time
+	bytes     uint64
+}
+
+// History is a ring buffer of byte-counter samples covering the last Window of time. It
+// computes windowed average throughput, correctly prorating the samples whose intervals
+// straddle the boundaries of the requested window.
+type History struct {
+	mu      sync.Mutex
+	window  time.Duration
+	records []record
+}
+
+// NewHistory returns a History that retains enough samples to answer Average queries over
+// any window up to length window.
+func NewHistory(window time.Duration) *History {
+	return &History{window: window}
+}
+
+// Add records the cumulative byte counter value observed at t. Samples must be added in
+// non-decreasing timestamp order.
+func (h *History) Add(t time.Time, bytes uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, record{timestamp: t, bytes: bytes})
+
+	// Trim everything older than the window, except the single most recent record at or
+	// before the cutoff: that one is still needed to prorate the oldest interval.
+	cutoff := t.Add(-h.window)
+	keepFrom := 0
+	for i, r := range h.records {
+		if r.timestamp.After(cutoff) {
+			break
+		}
+		keepFrom = i
+	}
+	if keepFrom > 0 {
+		h.records = append([]record(nil), h.records[keepFrom:]...)
+	}
+}
+
+// Average returns the average throughput, in bytes/second, transferred during [from, to].
+// It returns 0 if there isn't at least two samples overlapping that range.
+func (h *History) Average(from, to time.Time) float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	duration := to.Sub(from).Seconds()
+	if duration <= 0 || len(h.records) < 2 {
+		return 0
+	}
+
+	var totalBytes float64
+	for i := 0; i < len(h.records)-1; i++ {
+		a, b := h.records[i], h.records[i+1]
+		intervalDur := b.timestamp.Sub(a.timestamp)
+		if intervalDur <= 0 {
+			continue
+		}
+		overlapStart, overlapEnd := a.timestamp, b.timestamp
+		if from.After(overlapStart) {
+			overlapStart = from
+		}
+		if to.Before(overlapEnd) {
+			overlapEnd = to
+		}
+		overlap := overlapEnd.Sub(overlapStart)
+		if overlap <= 0 {
+			continue
+		}
+		deltaBytes := float64(b.bytes - a.bytes)
+		totalBytes += deltaBytes * (overlap.Seconds() / intervalDur.Seconds())
+	}
+	return totalBytes / duration
+}
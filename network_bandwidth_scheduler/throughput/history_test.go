@@ -0,0 +1,60 @@
+package throughput
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistoryAverage(t *testing.T) {
+	base := time.Date(2023, 12, 30, 0, 0, 0, 0, time.UTC)
+	h := NewHistory(10 * time.Second)
+	// 1000 bytes/sec, one sample per second.
+	for i := 0; i <= 5; i++ {
+		h.Add(base.Add(time.Duration(i)*time.Second), uint64(i*1000))
+	}
+
+	got := h.Average(base, base.Add(5*time.Second))
+	if want := 1000.0; got != want {
+		t.Fatalf("Average() = %v, want %v", got, want)
+	}
+}
+
+func TestHistoryAveragePartialInterval(t *testing.T) {
+	base := time.Date(2023, 12, 30, 0, 0, 0, 0, time.UTC)
+	h := NewHistory(10 * time.Second)
+	h.Add(base, 0)
+	h.Add(base.Add(2*time.Second), 2000) // 1000 bytes/sec across [base, base+2s]
+
+	// Query a sub-interval that straddles the middle of the only recorded interval: half
+	// of the 2000 bytes should be attributed to it.
+	got := h.Average(base.Add(500*time.Millisecond), base.Add(1500*time.Millisecond))
+	if want := 1000.0; got != want {
+		t.Fatalf("Average() = %v, want %v", got, want)
+	}
+}
+
+func TestHistoryAverageTooFewSamples(t *testing.T) {
+	h := NewHistory(10 * time.Second)
+	now := time.Now()
+	h.Add(now, 100)
+	if got := h.Average(now, now.Add(time.Second)); got != 0 {
+		t.Fatalf("Average() = %v, want 0 with a single sample", got)
+	}
+}
+
+func TestHistoryTrimsOldRecordsButKeepsBoundary(t *testing.T) {
+	base := time.Date(2023, 12, 30, 0, 0, 0, 0, time.UTC)
+	h := NewHistory(3 * time.Second)
+	for i := 0; i <= 10; i++ {
+		h.Add(base.Add(time.Duration(i)*time.Second), uint64(i*1000))
+	}
+	// Querying a window fully within the retention period should still work, which means
+	// the ring buffer must have kept at least one record at/before the cutoff to
+	// correctly prorate the oldest interval.
+	from := base.Add(7500 * time.Millisecond) // straddles [7s, 8s]; 7s is the cutoff record
+	to := base.Add(9500 * time.Millisecond)
+	got := h.Average(from, to)
+	if want := 1000.0; got != want {
+		t.Fatalf("Average() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+package throughput
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/karagog/clock-go"
+)
+
+// Monitor periodically samples a network interface's rx/tx byte counters and keeps a
+// History of each, so callers can ask how busy the interface has recently been.
+type Monitor struct {
+	nic     string
+	sampler Sampler
+	clock   clock.Clock
+	rx, tx  *History
+}
+
+// NewMonitor returns a Monitor for nic that samples via sampler, keeping window of
+// history for both rx and tx.
+func NewMonitor(nic string, sampler Sampler, clock clock.Clock, window time.Duration) *Monitor {
+	return &Monitor{
+		nic:     nic,
+		sampler: sampler,
+		clock:   clock,
+		rx:      NewHistory(window),
+		tx:      NewHistory(window),
+	}
+}
+
+// Run samples the interface every interval until ctx is done.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	for {
+		rx, tx, err := m.sampler.Sample(m.nic)
+		if err != nil {
+			log.Printf("Error sampling throughput for '%s': %v\n", m.nic, err)
+		} else {
+			now := m.clock.Now()
+			m.rx.Add(now, rx)
+			m.tx.Add(now, tx)
+		}
+
+		t := m.clock.NewTimer(interval)
+		select {
+		case <-t.C():
+			t.Stop()
+		case <-ctx.Done():
+			t.Stop()
+			return
+		}
+	}
+}
+
+// RxKbps returns the average download throughput, in Kbps, measured between from and to.
+func (m *Monitor) RxKbps(from, to time.Time) float64 {
+	return bytesPerSecToKbps(m.rx.Average(from, to))
+}
+
+// TxKbps returns the average upload throughput, in Kbps, measured between from and to.
+func (m *Monitor) TxKbps(from, to time.Time) float64 {
+	return bytesPerSecToKbps(m.tx.Average(from, to))
+}
+
+func bytesPerSecToKbps(bytesPerSec float64) float64 {
+	return bytesPerSec * 8 / 1000
+}
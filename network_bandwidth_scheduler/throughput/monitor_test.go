@@ -0,0 +1,52 @@
+package throughput
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/karagog/clock-go/simulated"
+)
+
+// fakeSampler returns byte counters from a preset, advancing list each call, for tests
+// that want to inject synthetic throughput.
+type fakeSampler struct {
+	calls  int
+	rx, tx []uint64
+}
+
+func (f *fakeSampler) Sample(nic string) (uint64, uint64, error) {
+	i := f.calls
+	if i >= len(f.rx) {
+		i = len(f.rx) - 1
+	}
+	f.calls++
+	return f.rx[i], f.tx[i], nil
+}
+
+func TestMonitorRun(t *testing.T) {
+	clk := simulated.NewClock(time.Date(2023, 12, 30, 0, 0, 0, 0, time.UTC))
+	sampler := &fakeSampler{rx: []uint64{0, 1000, 2000}, tx: []uint64{0, 500, 1000}}
+	m := NewMonitor("eth0", sampler, clk, 10*time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Run(ctx, time.Second)
+	}()
+
+	start := clk.Now()
+	time.Sleep(20 * time.Millisecond)
+	clk.Advance(time.Second)
+	time.Sleep(20 * time.Millisecond)
+	clk.Advance(time.Second)
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-done
+
+	gotRx := m.RxKbps(start, clk.Now())
+	if want := 8.0; gotRx != want { // 1000 bytes/sec average => 8kbps
+		t.Fatalf("RxKbps() = %v, want %v", gotRx, want)
+	}
+}
@@ -0,0 +1,47 @@
+// Package throughput measures a network interface's recent byte counters and exposes
+// windowed average throughput, so callers can adapt behavior (e.g. throttling limits) to
+// how busy an interface actually is.
+package throughput
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Sampler reads the current cumulative rx/tx byte counters for a network interface. It's
+// an interface so tests can inject synthetic throughput instead of reading /sys.
+type Sampler interface {
+	Sample(nic string) (rxBytes, txBytes uint64, err error)
+}
+
+// SysfsSampler reads byte counters from /sys/class/net/<nic>/statistics, which is how the
+// kernel exposes per-interface traffic counters on Linux.
+type SysfsSampler struct{}
+
+func (SysfsSampler) Sample(nic string) (rxBytes, txBytes uint64, err error) {
+	rxBytes, err = readCounter(nic, "rx_bytes")
+	if err != nil {
+		return 0, 0, err
+	}
+	txBytes, err = readCounter(nic, "tx_bytes")
+	if err != nil {
+		return 0, 0, err
+	}
+	return rxBytes, txBytes, nil
+}
+
+func readCounter(nic, stat string) (uint64, error) {
+	path := filepath.Join("/sys/class/net", nic, "statistics", stat)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("reading %s: %w", path, err)
+	}
+	n, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return n, nil
+}
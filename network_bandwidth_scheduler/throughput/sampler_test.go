@@ -0,0 +1,10 @@
+package throughput
+
+import "testing"
+
+func TestSysfsSamplerMissingInterface(t *testing.T) {
+	var s SysfsSampler
+	if _, _, err := s.Sample("nonexistent-nic-xyz"); err == nil {
+		t.Fatal("Got nil error, want error for a nic that doesn't exist")
+	}
+}